@@ -0,0 +1,80 @@
+package bgpls
+
+import "sync/atomic"
+
+// defaultEventQueueHighWaterMark is the default depth an eventQueue can
+// reach before it emits a single EventQueueOverflow warning.
+const defaultEventQueueHighWaterMark = 1024
+
+// eventQueue is an unbounded, FIFO event queue modeled on eapache/channels'
+// InfiniteChannel: In never blocks waiting on a slow consumer, because a
+// single internal goroutine drains it into an elastic slice-backed buffer
+// and feeds that buffer out to the consumer-visible Out channel at whatever
+// pace the consumer reads it.
+type eventQueue struct {
+	out           chan Event
+	in            chan Event
+	highWaterMark int
+	depth         int64
+}
+
+// newEventQueue creates an eventQueue that feeds out, the channel consumers
+// read from. highWaterMark <= 0 uses defaultEventQueueHighWaterMark.
+func newEventQueue(out chan Event, highWaterMark int) *eventQueue {
+	if highWaterMark <= 0 {
+		highWaterMark = defaultEventQueueHighWaterMark
+	}
+
+	q := &eventQueue{
+		out:           out,
+		in:            make(chan Event),
+		highWaterMark: highWaterMark,
+	}
+
+	go q.loop()
+
+	return q
+}
+
+// In enqueues event. It never blocks on the consumer; the only blocking is
+// the momentary handoff to the internal drain goroutine, which is always
+// ready to receive.
+func (q *eventQueue) In(event Event) {
+	q.in <- event
+}
+
+// Depth returns the number of events currently buffered and not yet
+// delivered to Out.
+func (q *eventQueue) Depth() int {
+	return int(atomic.LoadInt64(&q.depth))
+}
+
+func (q *eventQueue) loop() {
+	var buffer []Event
+	overflowed := false
+
+	for {
+		if len(buffer) == 0 {
+			buffer = append(buffer, <-q.in)
+			atomic.StoreInt64(&q.depth, int64(len(buffer)))
+		}
+
+		select {
+		case event := <-q.in:
+			buffer = append(buffer, event)
+			atomic.StoreInt64(&q.depth, int64(len(buffer)))
+
+			if !overflowed && len(buffer) > q.highWaterMark {
+				overflowed = true
+				buffer = append(buffer, newEventQueueOverflow(len(buffer)))
+			}
+		case q.out <- buffer[0]:
+			buffer = buffer[1:]
+			atomic.StoreInt64(&q.depth, int64(len(buffer)))
+
+			if overflowed && len(buffer) <= q.highWaterMark {
+				overflowed = false
+			}
+		}
+	}
+}
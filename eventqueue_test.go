@@ -0,0 +1,86 @@
+package bgpls
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventQueueFIFOOrder(t *testing.T) {
+	out := make(chan Event)
+	q := newEventQueue(out, 0)
+
+	pushed := make([]Event, 10)
+	for i := range pushed {
+		pushed[i] = newEventQueueOverflow(i)
+		q.In(pushed[i])
+	}
+
+	for i := range pushed {
+		assert.Equal(t, pushed[i], <-out)
+	}
+}
+
+func TestEventQueueInDoesNotBlockOnSlowConsumer(t *testing.T) {
+	out := make(chan Event)
+	q := newEventQueue(out, 0)
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < defaultEventQueueHighWaterMark*2; i++ {
+			q.In(newEventQueueOverflow(i))
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("In blocked waiting on a consumer that hasn't read anything yet")
+	}
+
+	for i := 0; i < defaultEventQueueHighWaterMark*2+1; i++ {
+		<-out
+	}
+}
+
+func TestEventQueueOverflowFiresOnce(t *testing.T) {
+	out := make(chan Event)
+	q := newEventQueue(out, 2)
+
+	for i := 0; i < 5; i++ {
+		q.In(newEventQueueOverflow(i))
+	}
+
+	received := 0
+	for i := 0; i < 6; i++ {
+		<-out
+		received++
+	}
+	assert.Equal(t, 6, received)
+
+	// 5 events plus exactly one overflow warning; nothing further follows.
+	select {
+	case <-out:
+		t.Fatal("EventQueueOverflow fired more than once")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestEventQueueDepthReturnsToZeroAfterDrain(t *testing.T) {
+	out := make(chan Event)
+	q := newEventQueue(out, 0)
+
+	for i := 0; i < 3; i++ {
+		q.In(newEventQueueOverflow(i))
+	}
+
+	for i := 0; i < 3; i++ {
+		<-out
+	}
+
+	assert.Eventually(t, func() bool {
+		return q.Depth() == 0
+	}, time.Second, time.Millisecond)
+}
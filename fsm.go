@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -51,6 +52,29 @@ var (
 	errInvalidStateTransition = errors.New("invalid state transition")
 )
 
+// AdminState describes whether a neighbor has been administratively enabled
+// or disabled via the neighbor interface's Enable()/Disable() methods, as
+// opposed to FSMState, which reflects the current state of the session
+// itself.
+type AdminState uint8
+
+// AdminState values
+const (
+	AdminStateUp AdminState = iota
+	AdminStateDown
+)
+
+func (s AdminState) String() string {
+	switch s {
+	case AdminStateUp:
+		return "up"
+	case AdminStateDown:
+		return "down"
+	default:
+		return "unknown admin state"
+	}
+}
+
 const (
 	connectRetryTime = time.Second * 5
 )
@@ -62,10 +86,27 @@ const (
 type fsm interface {
 	state() FSMState
 	shut()
+
+	// offerConn hands an inbound connection accepted by a Listener to the
+	// FSM. It blocks until the FSM is ready to consume it or
+	// connectRetryTime elapses, returning false in the latter case so the
+	// caller can close the connection.
+	offerConn(conn net.Conn) bool
+
+	// Enable and Disable move the neighbor between AdminStateUp and
+	// AdminStateDown without tearing down the FSM goroutine. Disable
+	// sends Cease and parks the session in IdleState; Enable resumes
+	// normal connect/retry behavior.
+	Enable()
+	Disable()
+
+	// EventQueueDepth returns the number of events currently buffered and
+	// not yet delivered to the Event channel passed to newFSM.
+	EventQueueDepth() int
 }
 
 type standardFSM struct {
-	events            chan Event
+	events            *eventQueue
 	disable           chan interface{}
 	neighbor          neighbor
 	localASN          uint32
@@ -81,12 +122,23 @@ type standardFSM struct {
 	holdTime          time.Duration
 	holdTimer         *time.Timer
 	connectRetryTimer *time.Timer
+	peerASN           uint32
+	connCh            chan net.Conn
+	adminStateCh      chan AdminState
+	adminState        AdminState
+	peerGRCapable     bool
+	peerGRRestartTime time.Duration
+	weAdvertisedGR    bool
+	holdingStale      bool
+	grRestartTimer    *time.Timer
+	grRestartCancel   chan struct{}
+	stopped           chan struct{}
 	*sync.RWMutex
 }
 
 func newFSM(neighbor neighbor, events chan Event, localASN uint32) fsm {
 	f := &standardFSM{
-		events:            events,
+		events:            newEventQueue(events, defaultEventQueueHighWaterMark),
 		disable:           make(chan interface{}),
 		neighbor:          neighbor,
 		localASN:          localASN,
@@ -97,6 +149,10 @@ func newFSM(neighbor neighbor, events chan Event, localASN uint32) fsm {
 		holdTime:          neighbor.config().HoldTime,
 		holdTimer:         time.NewTimer(0),
 		connectRetryTimer: time.NewTimer(0),
+		connCh:            make(chan net.Conn),
+		adminStateCh:      make(chan AdminState),
+		adminState:        AdminStateUp,
+		stopped:           make(chan struct{}),
 		RWMutex:           &sync.RWMutex{},
 	}
 
@@ -109,18 +165,186 @@ func newFSM(neighbor neighbor, events chan Event, localASN uint32) fsm {
 	return f
 }
 
+// shut stops the FSM goroutine for good. It is safe to call more than once
+// or concurrently with itself: stopped is closed once loop() has returned,
+// so a send racing against (or arriving after) that closure falls through
+// rather than blocking forever on a goroutine that's no longer receiving.
 func (f *standardFSM) shut() {
-	f.RLock()
-	if f.s == DisabledState {
-		f.RUnlock()
+	select {
+	case f.disable <- nil:
+	case <-f.stopped:
 		return
 	}
-	f.RUnlock()
-
-	f.disable <- nil
 	<-f.disable
 }
 
+// EventQueueDepth reports how many events are buffered inside the FSM's
+// event queue waiting to be delivered to the consumer-visible channel.
+func (f *standardFSM) EventQueueDepth() int {
+	return f.events.Depth()
+}
+
+func (f *standardFSM) offerConn(conn net.Conn) bool {
+	select {
+	case f.connCh <- conn:
+		return true
+	case <-time.After(connectRetryTime):
+		return false
+	}
+}
+
+// Enable administratively re-enables a neighbor previously paused with
+// Disable. It is a no-op if the FSM has been shut down entirely via shut().
+// Racing a shut() can't leave this blocked forever: it selects against
+// stopped rather than checking f.s and sending unconditionally, since a
+// stale "not yet disabled" read can go stale the instant after it's taken.
+func (f *standardFSM) Enable() {
+	select {
+	case f.adminStateCh <- AdminStateUp:
+	case <-f.stopped:
+	}
+}
+
+// Disable administratively pauses a neighbor: the FSM sends Cease, tears
+// down any active connection, and parks in IdleState until a later Enable
+// call. Unlike shut(), the FSM goroutine keeps running. It is a no-op if the
+// FSM has been shut down entirely via shut(). See Enable for why this
+// selects against stopped instead of checking f.s.
+func (f *standardFSM) Disable() {
+	select {
+	case f.adminStateCh <- AdminStateDown:
+	case <-f.stopped:
+	}
+}
+
+// racedOpen is the result of exchanging OPEN messages directly on a raced
+// connection, used by resolveCollision to decide a winner before either
+// connection is handed to the rest of the FSM.
+type racedOpen struct {
+	conn net.Conn
+	open *openMessage
+	err  error
+}
+
+// raceOpen writes the already-built OPEN message b to conn and reads the
+// peer's OPEN back from it, bypassing the FSM's normal per-connection
+// reader goroutine. It exists only to let resolveCollision learn the
+// peer's actual BGP Identifier before the connection collision (RFC 4271
+// §6.8) is resolved; once a winner is chosen, openConnectionPostCollision
+// takes over the connection without resending or re-reading OPEN. b is
+// built once by resolveCollision before the race starts, not recomputed
+// per connection here: resolveCollision runs raceOpen concurrently on both
+// raced connections, and buildOpenMessage mutates FSM state
+// (f.weAdvertisedGR) that only the FSM's own loop goroutine may touch.
+func (f *standardFSM) raceOpen(conn net.Conn, b []byte) (*openMessage, error) {
+	if _, err := conn.Write(b); err != nil {
+		return nil, err
+	}
+
+	if err := conn.SetReadDeadline(time.Now().Add(connectRetryTime)); err != nil {
+		return nil, err
+	}
+	defer conn.SetReadDeadline(time.Time{})
+
+	buff := make([]byte, 4096)
+	n, err := conn.Read(buff)
+	if err != nil {
+		return nil, err
+	}
+
+	msgs, err := messagesFromBytes(buff[:n])
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range msgs {
+		if o, ok := m.(*openMessage); ok {
+			return o, nil
+		}
+	}
+
+	return nil, errors.New("peer did not send an open message")
+}
+
+// resolveCollision implements the RFC 4271 §6.8 connection collision
+// comparison: the system with the numerically larger BGP Identifier keeps
+// the connection it initiated (outbound) and closes the other. Since
+// neither connection has exchanged OPEN yet at the point a collision is
+// detected, both are raced through raceOpen so the comparison uses the
+// peer's actual BGP Identifier rather than a locally configured value. The
+// OPEN message is built and MRT-recorded once, here, before the race
+// starts: raceOpen runs concurrently on both connections, and
+// buildOpenMessage/recordMRTMessage touch FSM state (f.weAdvertisedGR,
+// f.conn, f.peerASN) that only a single goroutine may touch at a time. It
+// closes the loser and returns the winning connection along with the
+// peer's OPEN read from it; the caller must hand both to
+// openConnectionPostCollision rather than openConnection. Returns a nil
+// conn if neither side completed an OPEN exchange.
+func (f *standardFSM) resolveCollision(outbound, inbound net.Conn) (net.Conn, *openMessage) {
+	b, err := f.buildOpenMessage()
+	if err != nil {
+		f.logger.WithField(loggerErrorField, err).Warn("error building open message to resolve collision")
+		outbound.Close()
+		inbound.Close()
+		return nil, nil
+	}
+	f.recordMRTMessage(b)
+
+	results := make(chan racedOpen, 2)
+	for _, c := range []net.Conn{outbound, inbound} {
+		c := c
+		go func() {
+			o, err := f.raceOpen(c, b)
+			results <- racedOpen{conn: c, open: o, err: err}
+		}()
+	}
+
+	byConn := make(map[net.Conn]racedOpen, 2)
+	for i := 0; i < 2; i++ {
+		r := <-results
+		byConn[r.conn] = r
+	}
+
+	outRes, inRes := byConn[outbound], byConn[inbound]
+
+	if outRes.err != nil && inRes.err != nil {
+		f.logger.WithField(loggerErrorField, outRes.err).Warn("error exchanging open message with raced connection")
+		outbound.Close()
+		inbound.Close()
+		return nil, nil
+	}
+	if outRes.err != nil {
+		f.logger.WithField(loggerErrorField, outRes.err).Warn("error exchanging open message on locally-initiated connection, keeping remotely-initiated connection")
+		outbound.Close()
+		return inbound, inRes.open
+	}
+	if inRes.err != nil {
+		f.logger.WithField(loggerErrorField, inRes.err).Warn("error exchanging open message on remotely-initiated connection, keeping locally-initiated connection")
+		inbound.Close()
+		return outbound, outRes.open
+	}
+
+	localRouterID := f.neighbor.config().RouterID.To4()
+	if localRouterID == nil {
+		f.logger.Warn("connection collision detected but RouterID is not configured, keeping locally-initiated connection")
+		inbound.Close()
+		return outbound, outRes.open
+	}
+
+	localID := binary.BigEndian.Uint32(localRouterID)
+	remoteID := inRes.open.bgpID
+
+	if localID > remoteID {
+		f.logger.Info("connection collision detected, keeping locally-initiated connection")
+		inbound.Close()
+		return outbound, outRes.open
+	}
+
+	f.logger.Info("connection collision detected, keeping remotely-initiated connection")
+	outbound.Close()
+	return inbound, inRes.open
+}
+
 func (f *standardFSM) transitionAndPanicOnErr(state FSMState) {
 	err := f.transition(state)
 	if err != nil {
@@ -129,6 +353,18 @@ func (f *standardFSM) transitionAndPanicOnErr(state FSMState) {
 }
 
 func (f *standardFSM) idle() FSMState {
+	// If we got here via an administrative Disable, park here selecting
+	// on adminStateCh instead of immediately retrying the connection,
+	// until a later Enable resumes normal connect/retry behavior.
+	for f.adminState == AdminStateDown {
+		select {
+		case <-f.disable:
+			return DisabledState
+		case s := <-f.adminStateCh:
+			f.adminState = s
+		}
+	}
+
 	return ConnectState
 }
 
@@ -139,99 +375,284 @@ func (f *standardFSM) cleanupConn() {
 	close(f.msgCh)
 }
 
-func (f *standardFSM) connect() FSMState {
+// teardownConn closes the current connection. If the session being torn
+// down had reached EstablishedState and both sides advertised Graceful
+// Restart (RFC 4724), previously-learned paths are retained as stale rather
+// than flushed: an EventNeighborStalePathsRetained is emitted and a restart
+// timer is started, after which an EventNeighborStalePathsFlushed fires
+// unless the session re-establishes and the peer sends End-of-RIB first.
+func (f *standardFSM) teardownConn() {
+	if f.state() == EstablishedState && f.peerGRCapable && f.weAdvertisedGR {
+		f.Lock()
+		f.holdingStale = true
+		f.Unlock()
+
+		f.events.In(newEventNeighborStalePathsRetained(f.neighbor.config()))
+
+		// A previous restart cycle's timer/goroutine can still be
+		// outstanding here if the session re-established and dropped
+		// again before that timer fired or an EOR cancelled it. Stop and
+		// cancel it before replacing it, so it can't go on to flush stale
+		// paths on its own, independent of this new restart cycle.
+		if f.grRestartTimer != nil {
+			f.grRestartTimer.Stop()
+		}
+		if f.grRestartCancel != nil {
+			close(f.grRestartCancel)
+		}
+
+		timer := time.NewTimer(f.peerGRRestartTime)
+		cancel := make(chan struct{})
+		f.grRestartTimer = timer
+		f.grRestartCancel = cancel
+		go f.waitForGRTimerExpiry(timer, cancel)
+	}
+
+	f.cleanupConn()
+}
+
+// waitForGRTimerExpiry flushes stale paths once the Graceful Restart timer
+// started in teardownConn fires, unless the session already re-established
+// and consumed the stale paths itself (see established's End-of-RIB
+// handling), in which case cancel is closed so this goroutine doesn't leak
+// blocked on a timer that Stop() alone can't wake it from.
+func (f *standardFSM) waitForGRTimerExpiry(timer *time.Timer, cancel chan struct{}) {
+	select {
+	case <-timer.C:
+	case <-cancel:
+		return
+	}
+
+	f.Lock()
+	if !f.holdingStale {
+		f.Unlock()
+		return
+	}
+	f.holdingStale = false
+	f.Unlock()
+
+	f.events.In(newEventNeighborStalePathsFlushed(f.neighbor.config()))
+}
+
+// buildOpenMessage creates and serializes our OPEN message for this
+// session, including the Graceful Restart capability if configured.
+// Factored out of openConnection so resolveCollision's raceOpen can send
+// the same OPEN on a raced connection before it's handed to the FSM.
+func (f *standardFSM) buildOpenMessage() ([]byte, error) {
+	bgpID := f.neighbor.config().RouterID
+	if bgpID == nil {
+		bgpID = f.neighbor.config().Address
+	}
+
+	o, err := newOpenMessage(f.localASN, f.holdTime, bgpID)
+	if err != nil {
+		return nil, err
+	}
+
+	if grTime := f.neighbor.config().GracefulRestartTime; grTime > 0 {
+		grCap := newCapGracefulRestart(uint16(grTime/time.Second), false, grAfiSafi{
+			afi:  uint16(BgpLsAFI),
+			safi: uint8(BgpLsSAFI),
+		})
+
+		for _, p := range o.optParams {
+			if capParam, ok := p.(*capabilityOptParam); ok {
+				capParam.caps = append(capParam.caps, grCap)
+			}
+		}
+
+		f.weAdvertisedGR = true
+	}
+
+	b, err := o.serialize()
+	if err != nil {
+		f.logger.WithField(loggerErrorField, err).Panic("bug serializing open message")
+	}
+
+	return b, nil
+}
+
+// openConnection takes ownership of conn, starts the reader goroutine, and
+// sends our OPEN message on it, returning the next FSM state.
+func (f *standardFSM) openConnection(conn net.Conn) FSMState {
+	f.readerErr = make(chan error)
+	f.closeReader = make(chan struct{})
+	f.readerClosed = make(chan struct{})
+	f.msgCh = make(chan Message)
+	f.conn = conn
+	go f.read()
+
+	b, err := f.buildOpenMessage()
+	if err != nil {
+		f.events.In(newEventNeighborErr(f.neighbor.config(), fmt.Errorf("error creating open message: %v", err)))
+
+		f.teardownConn()
+		return IdleState
+	}
+
+	_, err = f.conn.Write(b)
+	if err == nil {
+		f.recordMRTMessage(b)
+	}
+	if err != nil {
+		f.events.In(newEventNeighborErr(f.neighbor.config(), fmt.Errorf("error sending open message: %v", err)))
+
+		f.teardownConn()
+		return ConnectState
+	}
+
+	return OpenSentState
+}
+
+// openConnectionPostCollision takes ownership of conn after
+// resolveCollision has already exchanged OPEN messages directly on it via
+// raceOpen, and fast-forwards past the normal openSent() message loop
+// straight to validating the peer's OPEN and sending our KEEPALIVE, since
+// this connection's OPEN handshake already happened before it was handed
+// to the FSM.
+func (f *standardFSM) openConnectionPostCollision(conn net.Conn, peerOpen *openMessage) FSMState {
+	// transition() only allows OpenConfirmState to follow OpenSentState, so
+	// record the OPEN already sent by raceOpen as a pass through OpenSent.
+	f.transitionAndPanicOnErr(OpenSentState)
+
 	f.readerErr = make(chan error)
 	f.closeReader = make(chan struct{})
 	f.readerClosed = make(chan struct{})
 	f.msgCh = make(chan Message)
-	dialer := &net.Dialer{}
-	ctx, cancel := context.WithCancel(context.Background())
+	f.conn = conn
+	go f.read()
+
+	f.holdTimer.Reset(f.holdTime)
+
+	if err := f.validateOpen(peerOpen); err != nil {
+		return f.handleErr(err, IdleState)
+	}
+
+	if err := f.sendKeepAlive(); err != nil {
+		return f.handleErr(err, IdleState)
+	}
+
+	f.drainAndResetHoldTimer()
+	return OpenConfirmState
+}
+
+func (f *standardFSM) connect() FSMState {
+	f.connectRetryTimer.Reset(connectRetryTime)
+
+	passive := f.neighbor.config().Passive
 	connectErrorChan := make(chan error)
 	connChan := make(chan net.Conn)
-	f.connectRetryTimer.Reset(connectRetryTime)
 
-	go func() {
-		conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(f.neighbor.config().Address.String(), "179"))
-		if err != nil {
-			connectErrorChan <- err
-			return
+	var cancel context.CancelFunc
+	if !passive {
+		dialer := &net.Dialer{}
+		if password := f.neighbor.config().Password; password != "" {
+			dialer.Control = func(network, address string, c syscall.RawConn) error {
+				return setTCPMD5Sig(c, f.neighbor.config().Address, password)
+			}
 		}
 
-		connChan <- conn
-	}()
+		var ctx context.Context
+		ctx, cancel = context.WithCancel(context.Background())
+
+		go func() {
+			conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(f.neighbor.config().Address.String(), "179"))
+			if err != nil {
+				connectErrorChan <- err
+				return
+			}
+
+			connChan <- conn
+		}()
+	}
 
 	select {
 	case <-f.disable:
-		cancel()
+		if cancel != nil {
+			cancel()
+		}
 		return DisabledState
 	case <-f.connectRetryTimer.C:
-		cancel()
+		if cancel != nil {
+			cancel()
+		}
+		if passive {
+			// nothing dialed, just retry the wait for an inbound conn
+			return ConnectState
+		}
 		select {
 		case conn := <-connChan:
-			f.conn = conn
-			go f.read()
+			return f.openConnection(conn)
 		case <-connectErrorChan:
 			return ConnectState
 		}
 	case err := <-connectErrorChan:
-		cancel()
-
-		event := newEventNeighborErr(f.neighbor.config(), fmt.Errorf("error connecting to neighbor: %v", err))
-		select {
-		case f.events <- event:
-		case <-f.disable:
-			if !f.connectRetryTimer.Stop() {
-				<-f.connectRetryTimer.C
-			}
-			return DisabledState
+		if cancel != nil {
+			cancel()
 		}
 
+		f.events.In(newEventNeighborErr(f.neighbor.config(), fmt.Errorf("error connecting to neighbor: %v", err)))
+
 		if !f.connectRetryTimer.Stop() {
 			<-f.connectRetryTimer.C
 		}
 		return ActiveState
 	case conn := <-connChan:
-		cancel()
+		if cancel != nil {
+			cancel()
+		}
 		if !f.connectRetryTimer.Stop() {
 			<-f.connectRetryTimer.C
 		}
-		f.conn = conn
-		go f.read()
-	}
 
-	o, err := newOpenMessage(f.localASN, f.holdTime, f.neighbor.config().Address)
-	if err != nil {
-		event := newEventNeighborErr(f.neighbor.config(), fmt.Errorf("error creating open message: %v", err))
+		// an inbound connection may have raced the outbound dial; if so
+		// this is a connection collision per RFC 4271 §6.8.
 		select {
-		case f.events <- event:
-		case <-f.disable:
-			f.cleanupConn()
-			return DisabledState
+		case inbound := <-f.connCh:
+			winner, peerOpen := f.resolveCollision(conn, inbound)
+			if winner == nil {
+				return ActiveState
+			}
+			return f.openConnectionPostCollision(winner, peerOpen)
+		default:
 		}
 
-		f.cleanupConn()
-		return IdleState
-	}
-	b, err := o.serialize()
-	if err != nil {
-		f.logger.WithField(loggerErrorField, err).Panic("bug serializing open message")
-	}
+		return f.openConnection(conn)
+	case conn := <-f.connCh:
+		if cancel != nil {
+			cancel()
+		}
+		if !f.connectRetryTimer.Stop() {
+			<-f.connectRetryTimer.C
+		}
 
-	_, err = f.conn.Write(b)
-	if err != nil {
-		event := newEventNeighborErr(f.neighbor.config(), fmt.Errorf("error sending open message: %v", err))
-		select {
-		case f.events <- event:
-		case <-f.disable:
-			f.cleanupConn()
-			return DisabledState
+		if !passive {
+			select {
+			case outbound := <-connChan:
+				winner, peerOpen := f.resolveCollision(outbound, conn)
+				if winner == nil {
+					return ActiveState
+				}
+				return f.openConnectionPostCollision(winner, peerOpen)
+			default:
+			}
+		}
+
+		return f.openConnection(conn)
+	case s := <-f.adminStateCh:
+		if cancel != nil {
+			cancel()
+		}
+		if !f.connectRetryTimer.Stop() {
+			<-f.connectRetryTimer.C
 		}
 
-		f.cleanupConn()
+		f.adminState = s
+		if s == AdminStateDown {
+			return IdleState
+		}
 		return ConnectState
 	}
-
-	return OpenSentState
 }
 
 func (f *standardFSM) active() FSMState {
@@ -242,6 +663,21 @@ func (f *standardFSM) active() FSMState {
 		return DisabledState
 	case <-f.connectRetryTimer.C:
 		return ConnectState
+	case conn := <-f.connCh:
+		if !f.connectRetryTimer.Stop() {
+			<-f.connectRetryTimer.C
+		}
+		return f.openConnection(conn)
+	case s := <-f.adminStateCh:
+		if !f.connectRetryTimer.Stop() {
+			<-f.connectRetryTimer.C
+		}
+
+		f.adminState = s
+		if s == AdminStateDown {
+			return IdleState
+		}
+		return ActiveState
 	}
 }
 
@@ -250,32 +686,19 @@ func (f *standardFSM) handleErr(err error, nextState FSMState) FSMState {
 		f.sendNotification(err.code, err.subcode, err.data)
 	}
 
-	event := newEventNeighborErr(f.neighbor.config(), err)
-	select {
-	case f.events <- event:
-	case <-f.disable:
-		f.drainHoldTimer()
-		f.cleanupConn()
-		return DisabledState
-	}
+	f.events.In(newEventNeighborErr(f.neighbor.config(), err))
 
 	f.drainHoldTimer()
-	f.cleanupConn()
+	f.teardownConn()
 	return nextState
 }
 
 func (f *standardFSM) handleHoldTimerExpired(nextState FSMState) FSMState {
 	f.sendHoldTimerExpired()
 
-	event := newEventNeighborHoldTimerExpired(f.neighbor.config())
-	select {
-	case f.events <- event:
-	case <-f.disable:
-		f.cleanupConn()
-		return DisabledState
-	}
+	f.events.In(newEventNeighborHoldTimerExpired(f.neighbor.config()))
 
-	f.cleanupConn()
+	f.teardownConn()
 	return nextState
 }
 
@@ -309,6 +732,14 @@ func (f *standardFSM) read() {
 			}
 
 			for _, m := range msgs {
+				// A single read can contain more than one framed BGP
+				// message, so re-serialize each one individually rather
+				// than logging the raw socket buffer as a single MRT
+				// entry.
+				if mb, err := m.serialize(); err == nil {
+					f.recordMRTMessage(mb)
+				}
+
 				select {
 				case f.msgCh <- m:
 				case <-f.closeReader:
@@ -327,48 +758,53 @@ func (f *standardFSM) openSent() FSMState {
 	// should already be drained if previously set
 	f.holdTimer.Reset(f.holdTime)
 
-	select {
-	case <-f.disable:
-		f.sendCease()
-		f.drainHoldTimer()
-		f.cleanupConn()
-		return DisabledState
-	case err := <-f.readerErr:
-		return f.handleErr(err, ActiveState)
-	case <-f.holdTimer.C:
-		return f.handleHoldTimerExpired(IdleState)
-	case m := <-f.msgCh:
-		open, isOpen := m.(*openMessage)
-		if !isOpen {
-			notif, isNotif := m.(*NotificationMessage)
-			if isNotif {
-				event := newEventNeighborNotificationReceived(f.neighbor.config(), notif)
-				select {
-				case f.events <- event:
-				case <-f.disable:
-					f.drainHoldTimer()
-					f.cleanupConn()
-					return DisabledState
-				}
+	for {
+		select {
+		case <-f.disable:
+			f.sendCease()
+			f.drainHoldTimer()
+			f.teardownConn()
+			return DisabledState
+		case s := <-f.adminStateCh:
+			f.adminState = s
+			if s != AdminStateDown {
+				continue
 			}
 
+			f.sendCease()
 			f.drainHoldTimer()
-			f.cleanupConn()
+			f.teardownConn()
 			return IdleState
-		}
+		case err := <-f.readerErr:
+			return f.handleErr(err, ActiveState)
+		case <-f.holdTimer.C:
+			return f.handleHoldTimerExpired(IdleState)
+		case m := <-f.msgCh:
+			open, isOpen := m.(*openMessage)
+			if !isOpen {
+				notif, isNotif := m.(*NotificationMessage)
+				if isNotif {
+					f.events.In(newEventNeighborNotificationReceived(f.neighbor.config(), notif))
+				}
 
-		err := f.validateOpen(open)
-		if err != nil {
-			return f.handleErr(err, IdleState)
-		}
+				f.drainHoldTimer()
+				f.teardownConn()
+				return IdleState
+			}
 
-		err = f.sendKeepAlive()
-		if err != nil {
-			return f.handleErr(err, IdleState)
-		}
+			err := f.validateOpen(open)
+			if err != nil {
+				return f.handleErr(err, IdleState)
+			}
+
+			err = f.sendKeepAlive()
+			if err != nil {
+				return f.handleErr(err, IdleState)
+			}
 
-		f.drainAndResetHoldTimer()
-		return OpenConfirmState
+			f.drainAndResetHoldTimer()
+			return OpenConfirmState
+		}
 	}
 }
 
@@ -379,6 +815,9 @@ func (f *standardFSM) sendKeepAlive() error {
 		f.logger.WithField(loggerErrorField, err).Panic("bug serializing keepalive message")
 	}
 	_, err = f.conn.Write(b)
+	if err == nil {
+		f.recordMRTMessage(b)
+	}
 	return err
 }
 
@@ -388,8 +827,18 @@ func (f *standardFSM) openConfirm() FSMState {
 		case <-f.disable:
 			f.sendCease()
 			f.drainHoldTimer()
-			f.cleanupConn()
+			f.teardownConn()
 			return DisabledState
+		case s := <-f.adminStateCh:
+			f.adminState = s
+			if s != AdminStateDown {
+				continue
+			}
+
+			f.sendCease()
+			f.drainHoldTimer()
+			f.teardownConn()
+			return IdleState
 		case err := <-f.readerErr:
 			return f.handleErr(err, IdleState)
 		case <-f.holdTimer.C:
@@ -408,14 +857,58 @@ func (f *standardFSM) openConfirm() FSMState {
 	}
 }
 
+// sendEndOfRib sends the minimal UPDATE message (empty withdrawn routes,
+// empty path attributes) RFC 4724 defines as End-of-RIB for a peer's
+// initial route sync.
+func (f *standardFSM) sendEndOfRib() error {
+	u := &UpdateMessage{}
+	b, err := u.serialize()
+	if err != nil {
+		return err
+	}
+
+	_, err = f.conn.Write(b)
+	if err == nil {
+		f.recordMRTMessage(b)
+	}
+	return err
+}
+
+// isEndOfRib reports whether m is an RFC 4724 End-of-RIB marker: an UPDATE
+// with no withdrawn routes, path attributes, or NLRI.
+func isEndOfRib(m *UpdateMessage) bool {
+	b, err := m.serialize()
+	if err != nil {
+		return false
+	}
+
+	// 19-byte BGP header + 2-byte withdrawn routes length (0) + 2-byte
+	// total path attribute length (0), and nothing else.
+	return len(b) == 23
+}
+
 func (f *standardFSM) established() FSMState {
+	if err := f.sendEndOfRib(); err != nil {
+		return f.handleErr(err, IdleState)
+	}
+
 	for {
 		select {
 		case <-f.disable:
 			f.sendCease()
 			f.drainHoldTimer()
-			f.cleanupConn()
+			f.teardownConn()
 			return DisabledState
+		case s := <-f.adminStateCh:
+			f.adminState = s
+			if s != AdminStateDown {
+				continue
+			}
+
+			f.sendCease()
+			f.drainHoldTimer()
+			f.teardownConn()
+			return IdleState
 		case err := <-f.readerErr:
 			return f.handleErr(err, IdleState)
 		case <-f.holdTimer.C:
@@ -433,44 +926,41 @@ func (f *standardFSM) established() FSMState {
 				f.drainAndResetHoldTimer()
 			case *UpdateMessage:
 				f.drainAndResetHoldTimer()
-				event := newEventNeighborUpdateReceived(f.neighbor.config(), m)
 
-				select {
-				case f.events <- event:
-				case <-f.disable:
-					f.sendCease()
-					f.drainHoldTimer()
-					f.cleanupConn()
-					return DisabledState
+				if isEndOfRib(m) {
+					f.Lock()
+					wasHoldingStale := f.holdingStale
+					f.holdingStale = false
+					f.Unlock()
+
+					if wasHoldingStale {
+						if f.grRestartTimer != nil {
+							f.grRestartTimer.Stop()
+						}
+						if f.grRestartCancel != nil {
+							close(f.grRestartCancel)
+							f.grRestartCancel = nil
+						}
+
+						f.events.In(newEventNeighborStalePathsFlushed(f.neighbor.config()))
+					}
 				}
+
+				f.events.In(newEventNeighborUpdateReceived(f.neighbor.config(), m))
 			case *NotificationMessage:
-				event := newEventNeighborNotificationReceived(f.neighbor.config(), m)
-				select {
-				case f.events <- event:
-				case <-f.disable:
-					f.drainHoldTimer()
-					f.cleanupConn()
-					return DisabledState
-				}
+				f.events.In(newEventNeighborNotificationReceived(f.neighbor.config(), m))
 
 				f.drainHoldTimer()
-				f.cleanupConn()
+				f.teardownConn()
 				return IdleState
 			case *openMessage:
-				event := newEventNeighborErr(f.neighbor.config(), errors.New("open message received while in established state"))
-				select {
-				case f.events <- event:
-				case <-f.disable:
-					f.drainHoldTimer()
-					f.cleanupConn()
-					return DisabledState
-				}
+				f.events.In(newEventNeighborErr(f.neighbor.config(), errors.New("open message received while in established state")))
 
 				openType := make([]byte, 1)
 				openType[0] = uint8(OpenMessageType)
 				f.sendNotification(NotifErrCodeMessageHeader, NotifErrSubcodeBadType, openType)
 				f.drainHoldTimer()
-				f.cleanupConn()
+				f.teardownConn()
 				return IdleState
 			}
 		}
@@ -482,18 +972,13 @@ func (f *standardFSM) loop() {
 		state := f.state()
 
 		if state != DisabledState {
-			event := newEventNeighborStateTransition(f.neighbor.config(), state)
-			select {
-			case f.events <- event:
-			case <-f.disable:
-				f.disable <- nil
-				return
-			}
+			f.events.In(newEventNeighborStateTransition(f.neighbor.config(), state, f.adminState))
 		}
 
 		switch state {
 		case DisabledState:
 			f.disable <- nil
+			close(f.stopped)
 			return
 		case IdleState:
 			f.transitionAndPanicOnErr(f.idle())
@@ -539,6 +1024,9 @@ func (f *standardFSM) sendNotification(code NotifErrCode, subcode NotifErrSubcod
 	}
 
 	_, err = f.conn.Write(b)
+	if err == nil {
+		f.recordMRTMessage(b)
+	}
 	return err
 }
 
@@ -555,6 +1043,8 @@ func (f *standardFSM) validateOpen(msg *openMessage) error {
 	}
 
 	var fourOctetAS, fourOctetAsFound, bgpLsAfFound bool
+	var peerGRCapable bool
+	var peerGRRestartTime time.Duration
 	if msg.asn == asTrans {
 		fourOctetAS = true
 	} else {
@@ -613,6 +1103,13 @@ func (f *standardFSM) validateOpen(msg *openMessage) error {
 				if cap.afi == BgpLsAFI && cap.safi == BgpLsSAFI {
 					bgpLsAfFound = true
 				}
+			case *capGracefulRestart:
+				peerGRRestartTime = time.Duration(cap.restartTime) * time.Second
+				for _, t := range cap.afiSafis {
+					if t.afi == uint16(BgpLsAFI) && t.safi == uint8(BgpLsSAFI) {
+						peerGRCapable = true
+					}
+				}
 			case *capUnknown:
 			}
 		}
@@ -643,9 +1140,72 @@ func (f *standardFSM) validateOpen(msg *openMessage) error {
 		}
 	}
 
+	f.peerASN = f.neighbor.config().ASN
+	f.peerGRCapable = peerGRCapable
+	f.peerGRRestartTime = peerGRRestartTime
+
 	return nil
 }
 
+// localAddr returns the local IP of the session's TCP connection, or the
+// zero IP if there is no connection yet.
+func (f *standardFSM) localAddr() net.IP {
+	if f.conn == nil {
+		return net.IPv4zero
+	}
+
+	tcpAddr, ok := f.conn.LocalAddr().(*net.TCPAddr)
+	if !ok {
+		return net.IPv4zero
+	}
+
+	return tcpAddr.IP
+}
+
+// recordMRTMessage logs b, a serialized BGP message, as an MRT
+// BGP4MP_MESSAGE_AS4 record if the neighbor is configured with an
+// MRTWriter. For sent messages b is the exact bytes written to the
+// connection; for received messages read() passes each parsed message
+// re-serialized, not the bytes as they were read from the socket.
+func (f *standardFSM) recordMRTMessage(b []byte) {
+	w := f.neighbor.config().MRTWriter
+	if w == nil {
+		return
+	}
+
+	entry := MRTEntry{
+		Timestamp: uint32(time.Now().Unix()),
+		Type:      mrtTypeBGP4MP,
+		Subtype:   mrtSubtypeBGP4MPMessageAS4,
+		Message:   newBGP4MPMessageAS4(f.peerASN, f.localASN, f.neighbor.config().Address, f.localAddr(), 0, b),
+	}
+
+	if err := w.Write(entry); err != nil {
+		f.logger.WithField(loggerErrorField, err).Warn("error writing MRT record")
+	}
+}
+
+// recordMRTStateChange logs an FSM transition as an MRT
+// BGP4MP_STATE_CHANGE_AS4 record if the neighbor is configured with an
+// MRTWriter.
+func (f *standardFSM) recordMRTStateChange(old, new FSMState) {
+	w := f.neighbor.config().MRTWriter
+	if w == nil {
+		return
+	}
+
+	entry := MRTEntry{
+		Timestamp: uint32(time.Now().Unix()),
+		Type:      mrtTypeBGP4MP,
+		Subtype:   mrtSubtypeBGP4MPStateChangeAS4,
+		Message:   newBGP4MPStateChangeAS4(f.peerASN, f.localASN, f.neighbor.config().Address, f.localAddr(), 0, old, new),
+	}
+
+	if err := w.Write(entry); err != nil {
+		f.logger.WithField(loggerErrorField, err).Warn("error writing MRT record")
+	}
+}
+
 func (f *standardFSM) state() FSMState {
 	f.RLock()
 	defer f.RUnlock()
@@ -654,43 +1214,49 @@ func (f *standardFSM) state() FSMState {
 
 func (f *standardFSM) transition(state FSMState) error {
 	f.Lock()
-	defer f.Unlock()
+
+	old := f.s
 
 	switch state {
 	case DisabledState:
 		f.s = DisabledState
-		return nil
 	case IdleState:
 		f.s = IdleState
-		return nil
 	case ConnectState:
 		if f.s == IdleState || f.s == ConnectState || f.s == ActiveState {
 			f.s = ConnectState
-			return nil
 		}
 	case ActiveState:
 		if f.s == ConnectState || f.s == ActiveState || f.s == OpenSentState {
 			f.s = ActiveState
-			return nil
 		}
 	case OpenSentState:
 		if f.s == ConnectState || f.s == ActiveState {
 			f.s = OpenSentState
-			return nil
 		}
 	case OpenConfirmState:
 		if f.s == OpenSentState || f.s == OpenConfirmState {
 			f.s = OpenConfirmState
-			return nil
 		}
 	case EstablishedState:
 		if f.s == OpenConfirmState || f.s == EstablishedState {
 			f.s = EstablishedState
-			return nil
 		}
 	default:
+		f.Unlock()
 		return errors.New("invalid state")
 	}
 
-	return errInvalidStateTransition
+	new := f.s
+	f.Unlock()
+
+	if new != state {
+		return errInvalidStateTransition
+	}
+
+	if new != old {
+		f.recordMRTStateChange(old, new)
+	}
+
+	return nil
 }
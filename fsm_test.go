@@ -0,0 +1,86 @@
+package bgpls
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestEnableDisableDoNotHangAgainstConcurrentShut guards against a race
+// where Enable/Disable check f.s, see the FSM hasn't been shut down yet,
+// and then block sending to adminStateCh after shut() has already stopped
+// the loop goroutine from reading it.
+func TestEnableDisableDoNotHangAgainstConcurrentShut(t *testing.T) {
+	n := &stubNeighbor{
+		c: &NeighborConfig{
+			Address:  net.ParseIP("192.0.2.20"),
+			ASN:      64512,
+			HoldTime: time.Second * 3,
+		},
+		offered: make(chan net.Conn, 1),
+	}
+
+	f := newFSM(n, make(chan Event, 64), 64512)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			f.Enable()
+		}()
+		go func() {
+			defer wg.Done()
+			f.Disable()
+		}()
+	}
+
+	go f.shut()
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Enable/Disable hung racing against a concurrent shut()")
+	}
+}
+
+func TestShutIsSafeToCallConcurrently(t *testing.T) {
+	n := &stubNeighbor{
+		c: &NeighborConfig{
+			Address:  net.ParseIP("192.0.2.21"),
+			ASN:      64512,
+			HoldTime: time.Second * 3,
+		},
+		offered: make(chan net.Conn, 1),
+	}
+
+	f := newFSM(n, make(chan Event, 64), 64512)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f.shut()
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("concurrent shut() calls hung")
+	}
+}
@@ -0,0 +1,91 @@
+package bgpls
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+var errMalformedCapGracefulRestart = errors.New("malformed graceful restart capability")
+
+// capCodeGracefulRestart is the capability code for RFC 4724 Graceful
+// Restart, assigned by IANA.
+const capCodeGracefulRestart uint8 = 64
+
+// capGracefulRestartRestarting is the R bit of the 4-bit Restart Flags
+// field (the top nibble of the Restart Flags/Restart Time word), set when
+// the sender is in the process of restarting.
+const capGracefulRestartRestarting uint8 = 0x8
+
+// capGracefulRestartForwardingPreserved is the F bit of a per-AFI/SAFI
+// Flags octet, set when forwarding state for that AFI/SAFI has been
+// preserved across the restart.
+const capGracefulRestartForwardingPreserved uint8 = 0x80
+
+// grAfiSafi is one AFI/SAFI/Flags tuple advertised in a Graceful Restart
+// capability, indicating the sender supports GR for that address family.
+type grAfiSafi struct {
+	afi   uint16
+	safi  uint8
+	flags uint8
+}
+
+// capGracefulRestart is the RFC 4724 Graceful Restart capability (code 64).
+type capGracefulRestart struct {
+	restarting  bool
+	restartTime uint16 // 12 significant bits
+	afiSafis    []grAfiSafi
+}
+
+func newCapGracefulRestart(restartTime uint16, restarting bool, afiSafis ...grAfiSafi) *capGracefulRestart {
+	return &capGracefulRestart{
+		restarting:  restarting,
+		restartTime: restartTime & 0x0FFF,
+		afiSafis:    afiSafis,
+	}
+}
+
+func (c *capGracefulRestart) capabilityCode() uint8 {
+	return capCodeGracefulRestart
+}
+
+func (c *capGracefulRestart) serialize() ([]byte, error) {
+	b := make([]byte, 2+4*len(c.afiSafis))
+
+	word := c.restartTime & 0x0FFF
+	if c.restarting {
+		word |= uint16(capGracefulRestartRestarting) << 12
+	}
+	binary.BigEndian.PutUint16(b[0:2], word)
+
+	for i, t := range c.afiSafis {
+		o := 2 + i*4
+		binary.BigEndian.PutUint16(b[o:o+2], t.afi)
+		b[o+2] = t.safi
+		b[o+3] = t.flags
+	}
+
+	return b, nil
+}
+
+func parseCapGracefulRestart(b []byte) (*capGracefulRestart, error) {
+	if len(b) < 2 || (len(b)-2)%4 != 0 {
+		return nil, errMalformedCapGracefulRestart
+	}
+
+	word := binary.BigEndian.Uint16(b[0:2])
+
+	c := &capGracefulRestart{
+		restarting:  word&(uint16(capGracefulRestartRestarting)<<12) != 0,
+		restartTime: word & 0x0FFF,
+	}
+
+	for o := 2; o < len(b); o += 4 {
+		c.afiSafis = append(c.afiSafis, grAfiSafi{
+			afi:   binary.BigEndian.Uint16(b[o : o+2]),
+			safi:  b[o+2],
+			flags: b[o+3],
+		})
+	}
+
+	return c, nil
+}
@@ -0,0 +1,60 @@
+package bgpls
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCapGracefulRestartRoundTrip(t *testing.T) {
+	c := newCapGracefulRestart(120, true, grAfiSafi{
+		afi:   uint16(BgpLsAFI),
+		safi:  uint8(BgpLsSAFI),
+		flags: capGracefulRestartForwardingPreserved,
+	})
+
+	b, err := c.serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := parseCapGracefulRestart(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, c.restarting, parsed.restarting)
+	assert.Equal(t, c.restartTime, parsed.restartTime)
+	assert.Equal(t, c.afiSafis, parsed.afiSafis)
+}
+
+func TestCapGracefulRestartRestartTimeTruncated(t *testing.T) {
+	c := newCapGracefulRestart(0xFFFF, false)
+	assert.Equal(t, uint16(0x0FFF), c.restartTime)
+}
+
+func TestCapGracefulRestartNotRestarting(t *testing.T) {
+	c := newCapGracefulRestart(60, false)
+
+	b, err := c.serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := parseCapGracefulRestart(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.False(t, parsed.restarting)
+	assert.Equal(t, uint16(60), parsed.restartTime)
+	assert.Empty(t, parsed.afiSafis)
+}
+
+func TestParseCapGracefulRestartMalformed(t *testing.T) {
+	_, err := parseCapGracefulRestart([]byte{0})
+	assert.Equal(t, errMalformedCapGracefulRestart, err)
+
+	_, err = parseCapGracefulRestart([]byte{0, 0, 0, 0, 0})
+	assert.Equal(t, errMalformedCapGracefulRestart, err)
+}
@@ -0,0 +1,184 @@
+package bgpls
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"syscall"
+
+	"github.com/sirupsen/logrus"
+)
+
+// bgpPort is the well-known TCP port BGP speakers listen on.
+const bgpPort = "179"
+
+var errListenerMD5Unsupported = errors.New("listener does not support installing a TCP-MD5 key")
+
+// Listener accepts inbound BGP TCP connections and hands each one to the
+// neighbor FSM whose configured Address matches the remote endpoint, so
+// Passive neighbors can come up without us dialing out.
+type Listener struct {
+	ln        net.Listener
+	logger    *logrus.Entry
+	mu        sync.RWMutex
+	neighbors map[string]neighbor
+	closeCh   chan struct{}
+}
+
+// NewListener creates a Listener that has not yet started accepting
+// connections; call ListenAndServe to bind and start the accept loop.
+func NewListener() *Listener {
+	return &Listener{
+		logger:    logrus.WithField("component", "bgp-listener"),
+		neighbors: make(map[string]neighbor),
+		closeCh:   make(chan struct{}),
+	}
+}
+
+// ListenAndServe binds :179 and accepts connections until Close is called.
+func (l *Listener) ListenAndServe() error {
+	ln, err := net.Listen("tcp", net.JoinHostPort("", bgpPort))
+	if err != nil {
+		return err
+	}
+
+	l.ln = ln
+
+	// Neighbors may have registered (and, if passworded, tried to install
+	// their TCP-MD5 key) before we had a socket to install it on; catch
+	// those up now that one exists.
+	l.mu.RLock()
+	for addrStr, n := range l.neighbors {
+		if password := n.config().Password; password != "" {
+			if err := l.installMD5Key(net.ParseIP(addrStr), password); err != nil {
+				l.logger.WithField(loggerErrorField, err).Warn("error installing TCP-MD5 key on listening socket")
+			}
+		}
+	}
+	l.mu.RUnlock()
+
+	go l.acceptLoop()
+
+	return nil
+}
+
+// register makes n eligible to receive inbound connections from addr. If n
+// is configured with a Password, the TCP-MD5 key is also installed on the
+// listening socket for addr: the kernel validates (or computes) the MD5
+// option against the listening socket's key table during the handshake
+// itself, before Accept() ever hands back a connection for handleConn to
+// fix up post-hoc.
+func (l *Listener) register(addr net.IP, n neighbor) {
+	l.mu.Lock()
+	l.neighbors[addr.String()] = n
+	l.mu.Unlock()
+
+	if password := n.config().Password; password != "" {
+		if err := l.installMD5Key(addr, password); err != nil {
+			l.logger.WithField(loggerErrorField, err).Warn("error installing TCP-MD5 key on listening socket")
+		}
+	}
+}
+
+// installMD5Key installs an RFC 2385 TCP-MD5 key for addr on the listening
+// socket. It is a no-op until ListenAndServe has bound the socket; callers
+// that register before then are caught up by ListenAndServe itself once
+// binding happens.
+func (l *Listener) installMD5Key(addr net.IP, password string) error {
+	if l.ln == nil {
+		return nil
+	}
+
+	syscallConnable, ok := l.ln.(interface {
+		SyscallConn() (syscall.RawConn, error)
+	})
+	if !ok {
+		return errListenerMD5Unsupported
+	}
+
+	rawConn, err := syscallConnable.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	return setTCPMD5Sig(rawConn, addr, password)
+}
+
+// unregister stops addr's neighbor from receiving inbound connections.
+func (l *Listener) unregister(addr net.IP) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.neighbors, addr.String())
+}
+
+// Close stops accepting new connections.
+func (l *Listener) Close() error {
+	close(l.closeCh)
+
+	if l.ln == nil {
+		return nil
+	}
+
+	return l.ln.Close()
+}
+
+func (l *Listener) acceptLoop() {
+	for {
+		conn, err := l.ln.Accept()
+		if err != nil {
+			select {
+			case <-l.closeCh:
+				return
+			default:
+				l.logger.WithField(loggerErrorField, err).Warn("error accepting inbound connection")
+				continue
+			}
+		}
+
+		go l.handleConn(conn)
+	}
+}
+
+func (l *Listener) handleConn(conn net.Conn) {
+	host, _, err := net.SplitHostPort(conn.RemoteAddr().String())
+	if err != nil {
+		conn.Close()
+		return
+	}
+
+	l.mu.RLock()
+	n, ok := l.neighbors[host]
+	l.mu.RUnlock()
+
+	if !ok {
+		l.logger.WithField("remote", host).Warn("rejecting inbound connection from unconfigured neighbor")
+		conn.Close()
+		return
+	}
+
+	if password := n.config().Password; password != "" {
+		tcpConn, ok := conn.(*net.TCPConn)
+		if !ok {
+			l.logger.WithField("remote", host).Warn("cannot install TCP-MD5 key on non-TCP connection")
+			conn.Close()
+			return
+		}
+
+		rawConn, err := tcpConn.SyscallConn()
+		if err != nil {
+			l.logger.WithField(loggerErrorField, err).Warn("error getting raw connection to install TCP-MD5 key")
+			conn.Close()
+			return
+		}
+
+		if err := setTCPMD5Sig(rawConn, n.config().Address, password); err != nil {
+			l.logger.WithField(loggerErrorField, err).Warn("error installing TCP-MD5 key on accepted connection")
+			conn.Close()
+			return
+		}
+	}
+
+	if !n.offerConn(conn) {
+		conn.Close()
+	}
+}
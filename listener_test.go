@@ -0,0 +1,120 @@
+package bgpls
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+// stubNeighbor is a minimal neighbor implementation for exercising Listener
+// wiring without spinning up a real FSM.
+type stubNeighbor struct {
+	c       *NeighborConfig
+	offered chan net.Conn
+}
+
+func (s *stubNeighbor) state() FSMState         { return IdleState }
+func (s *stubNeighbor) shut()                   {}
+func (s *stubNeighbor) Enable()                 {}
+func (s *stubNeighbor) Disable()                {}
+func (s *stubNeighbor) EventQueueDepth() int    { return 0 }
+func (s *stubNeighbor) config() *NeighborConfig { return s.c }
+
+func (s *stubNeighbor) offerConn(conn net.Conn) bool {
+	select {
+	case s.offered <- conn:
+		return true
+	default:
+		return false
+	}
+}
+
+// fakeAddrConn wraps a net.Conn to report an arbitrary RemoteAddr, since
+// net.Pipe's endpoints don't carry real network addresses.
+type fakeAddrConn struct {
+	net.Conn
+	remote net.Addr
+}
+
+func (c *fakeAddrConn) RemoteAddr() net.Addr { return c.remote }
+
+func TestListenerHandleConnRoutesToRegisteredNeighbor(t *testing.T) {
+	addr := net.ParseIP("192.0.2.1")
+	n := &stubNeighbor{
+		c:       &NeighborConfig{Address: addr},
+		offered: make(chan net.Conn, 1),
+	}
+
+	l := NewListener()
+	l.register(addr, n)
+
+	client, server := net.Pipe()
+	defer client.Close()
+
+	wrapped := &fakeAddrConn{Conn: server, remote: &net.TCPAddr{IP: addr, Port: 12345}}
+	l.handleConn(wrapped)
+
+	select {
+	case conn := <-n.offered:
+		if conn != wrapped {
+			t.Fatal("neighbor was offered a different connection than the one accepted")
+		}
+	default:
+		t.Fatal("registered neighbor was never offered the inbound connection")
+	}
+}
+
+func TestListenerHandleConnRejectsUnregisteredNeighbor(t *testing.T) {
+	l := NewListener()
+
+	client, server := net.Pipe()
+	defer client.Close()
+
+	wrapped := &fakeAddrConn{Conn: server, remote: &net.TCPAddr{IP: net.ParseIP("192.0.2.2"), Port: 12345}}
+	l.handleConn(wrapped)
+
+	buff := make([]byte, 1)
+	if _, err := client.Read(buff); err == nil {
+		t.Fatal("expected the unrecognized connection to be closed")
+	}
+}
+
+// TestListenerRoutesInboundConnectionToRegisteredNeighbor exercises the
+// real accept -> register -> offerConn path end to end over an actual TCP
+// socket, rather than mocking handleConn's input.
+func TestListenerRoutesInboundConnectionToRegisteredNeighbor(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l := NewListener()
+	l.ln = ln
+	go l.acceptLoop()
+	defer l.Close()
+
+	host, _, err := net.SplitHostPort(ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := net.ParseIP(host)
+
+	n := &stubNeighbor{
+		c:       &NeighborConfig{Address: addr},
+		offered: make(chan net.Conn, 1),
+	}
+	l.register(addr, n)
+
+	client, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	select {
+	case conn := <-n.offered:
+		conn.Close()
+	case <-time.After(time.Second):
+		t.Fatal("inbound connection was never routed to the registered neighbor")
+	}
+}
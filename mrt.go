@@ -0,0 +1,160 @@
+package bgpls
+
+import (
+	"bufio"
+	"encoding/binary"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// MRT record types/subtypes this package emits. See RFC 6396 and the
+// BGP4MP_ET/BGP4MP type registries maintained by IANA.
+const (
+	mrtTypeBGP4MP uint16 = 16
+
+	mrtSubtypeBGP4MPMessageAS4     uint16 = 4
+	mrtSubtypeBGP4MPStateChangeAS4 uint16 = 5
+)
+
+// MRTEntry is a single MRT record. Message is the already-encoded BGP4MP
+// payload; MRTWriter implementations are responsible for prefixing it with
+// the common MRT header before persisting it.
+type MRTEntry struct {
+	Timestamp uint32
+	Type      uint16
+	Subtype   uint16
+	Message   []byte
+}
+
+func (e *MRTEntry) serialize() []byte {
+	b := make([]byte, 12+len(e.Message))
+	binary.BigEndian.PutUint32(b[0:4], e.Timestamp)
+	binary.BigEndian.PutUint16(b[4:6], e.Type)
+	binary.BigEndian.PutUint16(b[6:8], e.Subtype)
+	binary.BigEndian.PutUint32(b[8:12], uint32(len(e.Message)))
+	copy(b[12:], e.Message)
+	return b
+}
+
+// MRTWriter is implemented by anything that can record MRT entries for
+// offline analysis, e.g. with bgpdump/libBGPdump.
+type MRTWriter interface {
+	Write(entry MRTEntry) error
+}
+
+// FileMRTWriter is an MRTWriter that appends records to a file on disk,
+// buffering writes and flushing them periodically rather than on every
+// record.
+type FileMRTWriter struct {
+	f             *os.File
+	w             *bufio.Writer
+	flushInterval time.Duration
+	mu            sync.Mutex
+	closeCh       chan struct{}
+	closeOnce     sync.Once
+}
+
+// NewFileMRTWriter opens (creating if necessary) the file at path and starts
+// flushing buffered records to it every flushInterval.
+func NewFileMRTWriter(path string, flushInterval time.Duration) (*FileMRTWriter, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &FileMRTWriter{
+		f:             f,
+		w:             bufio.NewWriter(f),
+		flushInterval: flushInterval,
+		closeCh:       make(chan struct{}),
+	}
+
+	go w.flushLoop()
+
+	return w, nil
+}
+
+func (w *FileMRTWriter) flushLoop() {
+	t := time.NewTicker(w.flushInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			w.mu.Lock()
+			w.w.Flush()
+			w.mu.Unlock()
+		case <-w.closeCh:
+			return
+		}
+	}
+}
+
+// Write appends entry to the underlying buffered writer.
+func (w *FileMRTWriter) Write(entry MRTEntry) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	_, err := w.w.Write(entry.serialize())
+	return err
+}
+
+// Close flushes any buffered records and closes the underlying file.
+func (w *FileMRTWriter) Close() error {
+	w.closeOnce.Do(func() {
+		close(w.closeCh)
+	})
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.w.Flush(); err != nil {
+		return err
+	}
+
+	return w.f.Close()
+}
+
+// ianaFSMState maps our FSMState to the state numbers the IANA BGP FSM State
+// registry expects in BGP4MP_STATE_CHANGE_AS4 records. DisabledState has no
+// IANA equivalent and is logged as Idle.
+func ianaFSMState(s FSMState) uint16 {
+	if s == DisabledState {
+		return uint16(IdleState)
+	}
+
+	return uint16(s)
+}
+
+func newBGP4MPMessageAS4(peerAS, localAS uint32, peerIP, localIP net.IP, ifIndex uint16, msg []byte) []byte {
+	b := make([]byte, 16+len(peerIP.To16())*2+len(msg))
+	binary.BigEndian.PutUint32(b[0:4], peerAS)
+	binary.BigEndian.PutUint32(b[4:8], localAS)
+	binary.BigEndian.PutUint16(b[8:10], ifIndex)
+
+	peer4 := peerIP.To4()
+	local4 := localIP.To4()
+	if peer4 != nil && local4 != nil {
+		binary.BigEndian.PutUint16(b[10:12], 1) // AFI_IPV4
+		copy(b[12:16], peer4)
+		copy(b[16:20], local4)
+		copy(b[20:], msg)
+		return b[:20+len(msg)]
+	}
+
+	binary.BigEndian.PutUint16(b[10:12], 2) // AFI_IPV6
+	copy(b[12:28], peerIP.To16())
+	copy(b[28:44], localIP.To16())
+	copy(b[44:], msg)
+	return b[:44+len(msg)]
+}
+
+func newBGP4MPStateChangeAS4(peerAS, localAS uint32, peerIP, localIP net.IP, ifIndex uint16, old, new FSMState) []byte {
+	states := make([]byte, 4)
+	binary.BigEndian.PutUint16(states[0:2], ianaFSMState(old))
+	binary.BigEndian.PutUint16(states[2:4], ianaFSMState(new))
+
+	return newBGP4MPMessageAS4(peerAS, localAS, peerIP, localIP, ifIndex, states)
+}
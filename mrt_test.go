@@ -0,0 +1,63 @@
+package bgpls
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMRTEntrySerialize(t *testing.T) {
+	e := &MRTEntry{
+		Timestamp: 1700000000,
+		Type:      mrtTypeBGP4MP,
+		Subtype:   mrtSubtypeBGP4MPMessageAS4,
+		Message:   []byte{0xAA, 0xBB, 0xCC},
+	}
+
+	b := e.serialize()
+
+	if len(b) != 12+len(e.Message) {
+		t.Fatalf("unexpected serialized length: %d", len(b))
+	}
+
+	assert.Equal(t, e.Timestamp, binary.BigEndian.Uint32(b[0:4]))
+	assert.Equal(t, e.Type, binary.BigEndian.Uint16(b[4:6]))
+	assert.Equal(t, e.Subtype, binary.BigEndian.Uint16(b[6:8]))
+	assert.Equal(t, uint32(len(e.Message)), binary.BigEndian.Uint32(b[8:12]))
+	assert.Equal(t, e.Message, b[12:])
+}
+
+func TestNewBGP4MPMessageAS4IPv4(t *testing.T) {
+	peer := net.ParseIP("192.0.2.1")
+	local := net.ParseIP("192.0.2.2")
+	msg := []byte{1, 2, 3}
+
+	b := newBGP4MPMessageAS4(64512, 64513, peer, local, 0, msg)
+
+	assert.Equal(t, uint32(64512), binary.BigEndian.Uint32(b[0:4]))
+	assert.Equal(t, uint32(64513), binary.BigEndian.Uint32(b[4:8]))
+	assert.Equal(t, uint16(1), binary.BigEndian.Uint16(b[10:12])) // AFI_IPV4
+	assert.Equal(t, peer.To4(), net.IP(b[12:16]))
+	assert.Equal(t, local.To4(), net.IP(b[16:20]))
+	assert.Equal(t, msg, b[20:])
+}
+
+func TestNewBGP4MPMessageAS4IPv6(t *testing.T) {
+	peer := net.ParseIP("2001:db8::1")
+	local := net.ParseIP("2001:db8::2")
+	msg := []byte{1, 2, 3}
+
+	b := newBGP4MPMessageAS4(64512, 64513, peer, local, 0, msg)
+
+	assert.Equal(t, uint16(2), binary.BigEndian.Uint16(b[10:12])) // AFI_IPV6
+	assert.Equal(t, peer.To16(), net.IP(b[12:28]))
+	assert.Equal(t, local.To16(), net.IP(b[28:44]))
+	assert.Equal(t, msg, b[44:])
+}
+
+func TestIanaFSMState(t *testing.T) {
+	assert.Equal(t, uint16(IdleState), ianaFSMState(DisabledState))
+	assert.Equal(t, uint16(EstablishedState), ianaFSMState(EstablishedState))
+}
@@ -10,6 +10,31 @@ type NeighborConfig struct {
 	Address  net.IP
 	ASN      uint32
 	HoldTime time.Duration
+
+	// RouterID is this speaker's BGP Identifier, used both in the OPEN
+	// message we send and to resolve connection collisions (RFC 4271
+	// §6.8) against this neighbor.
+	RouterID net.IP
+
+	// Passive, if set, disables active TCP connection attempts to this
+	// neighbor. The session only comes up once the neighbor connects to
+	// us via a Listener.
+	Passive bool
+
+	// MRTWriter, if set, receives an MRT (RFC 6396) BGP4MP_MESSAGE_AS4
+	// record for every message sent or received on this neighbor's
+	// session, and a BGP4MP_STATE_CHANGE_AS4 record on every FSM state
+	// transition.
+	MRTWriter MRTWriter
+
+	// GracefulRestartTime, if nonzero, advertises RFC 4724 Graceful
+	// Restart support with this restart time and retains stale paths
+	// across a session restart rather than flushing them immediately.
+	GracefulRestartTime time.Duration
+
+	// Password, if set, enables RFC 2385 TCP-MD5 authentication for this
+	// neighbor's session, both outbound and (via a Listener) inbound.
+	Password string
 }
 
 type neighbor interface {
@@ -19,15 +44,30 @@ type neighbor interface {
 
 type standardNeighbor struct {
 	fsm
-	c *NeighborConfig
+	c        *NeighborConfig
+	listener *Listener
 }
 
-func newNeighbor(routerID net.IP, localASN uint32, config *NeighborConfig, events chan Event) neighbor {
+// newNeighbor creates a neighbor and starts its FSM. routerID defaults
+// config.RouterID when the latter isn't set. If config.Passive is set and
+// listener is non-nil, the neighbor is registered with listener so inbound
+// connections from config.Address are routed to it instead of requiring an
+// outbound dial; shut() unregisters it again.
+func newNeighbor(routerID net.IP, localASN uint32, config *NeighborConfig, events chan Event, listener *Listener) neighbor {
+	if config.RouterID == nil {
+		config.RouterID = routerID
+	}
+
 	n := &standardNeighbor{
-		c: config,
+		c:        config,
+		listener: listener,
 	}
 
-	n.fsm = newFSM(n.config(), events, routerID, localASN, 179)
+	n.fsm = newFSM(n, events, localASN)
+
+	if config.Passive && listener != nil {
+		listener.register(config.Address, n)
+	}
 
 	return n
 }
@@ -35,3 +75,14 @@ func newNeighbor(routerID net.IP, localASN uint32, config *NeighborConfig, event
 func (n *standardNeighbor) config() *NeighborConfig {
 	return n.c
 }
+
+// shut tears down the FSM and, if this neighbor was registered with a
+// Listener for inbound connections, unregisters it so the Listener stops
+// routing new connections here.
+func (n *standardNeighbor) shut() {
+	n.fsm.shut()
+
+	if n.c.Passive && n.listener != nil {
+		n.listener.unregister(n.c.Address)
+	}
+}
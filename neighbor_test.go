@@ -0,0 +1,66 @@
+package bgpls
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestNewNeighborRegistersWithListenerWhenPassive(t *testing.T) {
+	l := NewListener()
+	addr := net.ParseIP("192.0.2.10")
+
+	n := newNeighbor(net.ParseIP("192.0.2.254"), 64512, &NeighborConfig{
+		Address:  addr,
+		ASN:      64513,
+		HoldTime: time.Second * 3,
+		Passive:  true,
+	}, make(chan Event, 16), l)
+	defer n.shut()
+
+	l.mu.RLock()
+	_, ok := l.neighbors[addr.String()]
+	l.mu.RUnlock()
+
+	if !ok {
+		t.Fatal("passive neighbor was not registered with the listener")
+	}
+}
+
+func TestNewNeighborShutUnregistersFromListener(t *testing.T) {
+	l := NewListener()
+	addr := net.ParseIP("192.0.2.11")
+
+	n := newNeighbor(net.ParseIP("192.0.2.254"), 64512, &NeighborConfig{
+		Address:  addr,
+		ASN:      64513,
+		HoldTime: time.Second * 3,
+		Passive:  true,
+	}, make(chan Event, 16), l)
+
+	n.shut()
+
+	l.mu.RLock()
+	_, ok := l.neighbors[addr.String()]
+	l.mu.RUnlock()
+
+	if ok {
+		t.Fatal("neighbor was still registered with the listener after shut()")
+	}
+}
+
+func TestNewNeighborDefaultsRouterID(t *testing.T) {
+	routerID := net.ParseIP("192.0.2.254")
+	config := &NeighborConfig{
+		Address:  net.ParseIP("192.0.2.12"),
+		ASN:      64513,
+		HoldTime: time.Second * 3,
+	}
+
+	n := newNeighbor(routerID, 64512, config, make(chan Event, 16), nil)
+	defer n.shut()
+
+	if !config.RouterID.Equal(routerID) {
+		t.Fatalf("expected RouterID to default to %s, got %s", routerID, config.RouterID)
+	}
+}
@@ -0,0 +1,38 @@
+//go:build linux
+
+package bgpls
+
+import (
+	"net"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// setTCPMD5Sig installs an RFC 2385 TCP-MD5 signature key for segments
+// to/from addr on the socket underlying c, via the Linux-specific
+// TCP_MD5SIG setsockopt. Passing an empty password removes the key.
+func setTCPMD5Sig(c syscall.RawConn, addr net.IP, password string) error {
+	sig := unix.TCPMD5Sig{
+		Keylen: uint16(len(password)),
+	}
+	copy(sig.Key[:], password)
+
+	if ip4 := addr.To4(); ip4 != nil {
+		sig.Addr.Family = unix.AF_INET
+		copy(sig.Addr.Data[2:6], ip4)
+	} else {
+		sig.Addr.Family = unix.AF_INET6
+		copy(sig.Addr.Data[6:22], addr.To16())
+	}
+
+	var sockErr error
+	err := c.Control(func(fd uintptr) {
+		sockErr = unix.SetsockoptTCPMD5Sig(int(fd), unix.IPPROTO_TCP, unix.TCP_MD5SIG, &sig)
+	})
+	if err != nil {
+		return err
+	}
+
+	return sockErr
+}
@@ -0,0 +1,17 @@
+//go:build !linux
+
+package bgpls
+
+import (
+	"errors"
+	"net"
+	"syscall"
+)
+
+var errTCPMD5SigUnsupported = errors.New("TCP-MD5 signature option is not supported on this platform")
+
+// setTCPMD5Sig is unimplemented outside Linux; RFC 2385 support is limited
+// to platforms exposing TCP_MD5SIG.
+func setTCPMD5Sig(c syscall.RawConn, addr net.IP, password string) error {
+	return errTCPMD5SigUnsupported
+}